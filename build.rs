@@ -0,0 +1,27 @@
+use std::process::Command;
+
+// Passes the git commit and build time to the binary, so a running build can
+// be identified. Either is left unset when the command to read it fails.
+fn main() {
+  if let Some(sha) = output("git", &["rev-parse", "--short", "HEAD"]) {
+    println!("cargo:rustc-env=UPSPEAK_GIT_SHA={}", sha);
+  }
+  if let Some(time) = output("date", &["-u", "+%Y-%m-%dT%H:%M:%SZ"]) {
+    println!("cargo:rustc-env=UPSPEAK_BUILD_TIME={}", time);
+  }
+  println!("cargo:rerun-if-changed=.git/HEAD");
+  println!("cargo:rerun-if-changed=.git/refs/heads");
+}
+
+fn output(program: &str, args: &[&str]) -> Option<String> {
+  let out = Command::new(program).args(args).output().ok()?;
+  if !out.status.success() {
+    return None;
+  }
+  let value = String::from_utf8(out.stdout).ok()?.trim().to_string();
+  if value.is_empty() {
+    None
+  } else {
+    Some(value)
+  }
+}
@@ -1,4 +1,9 @@
 #[tokio::main]
 pub async fn main() {
-  println!("Upspeak!")
+  println!(
+    "Upspeak! v{} ({}, built {})",
+    env!("CARGO_PKG_VERSION"),
+    option_env!("UPSPEAK_GIT_SHA").unwrap_or("unknown"),
+    option_env!("UPSPEAK_BUILD_TIME").unwrap_or("unknown")
+  )
 }
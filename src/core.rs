@@ -2,6 +2,12 @@ use anyhow::Error;
 
 type Id = i64;
 
+/// Maximum number of node IDs accepted by `NodeStore::get_many`.
+pub const NODE_BATCH_LIMIT: usize = 100;
+
+/// Maximum number of ancestors returned by `NodeStore::ancestors`.
+pub const ANCESTORS_DEPTH_LIMIT: usize = 100;
+
 pub struct Node {
   pub id: Id,
 }
@@ -24,7 +30,26 @@ pub struct Namespace {}
 
 pub trait NodeStore {
   fn get(&self, node_id: &Id) -> Result<Node, Error>;
+  /// Fetches the nodes for the given IDs in one lookup. IDs that don't exist
+  /// are omitted, duplicate IDs yield a single node, and the order of the
+  /// result is not guaranteed. Returns an error if more than
+  /// `NODE_BATCH_LIMIT` IDs are given.
+  fn get_many(&self, node_ids: &[Id]) -> Result<Vec<Node>, Error>;
+  /// Checks whether a node exists without fetching it. Returns `Ok(false)`
+  /// rather than an error for a missing ID. Archived nodes count as existing
+  /// until they are purged.
+  fn exists(&self, node_id: &Id) -> Result<bool, Error>;
   fn forks(&self, node_id: &Id) -> Result<Vec<Thread>, Error>;
   fn replies(&self, node_id: &Id) -> Result<Vec<Node>, Error>;
+  /// Counts the threads forked from a node without fetching them.
+  fn count_forks(&self, node_id: &Id) -> Result<i64, Error>;
+  /// Counts the replies to a node without fetching them.
+  fn count_replies(&self, node_id: &Id) -> Result<i64, Error>;
+  /// Fetches the chain of nodes a node replies to, following `in_reply_to`
+  /// up to the thread root. The result is ordered from the root down to the
+  /// node's direct parent and is empty for a root node. Returns an error if
+  /// the chain is longer than `ANCESTORS_DEPTH_LIMIT` nodes or contains a
+  /// cycle, so a returned chain always starts at the root.
+  fn ancestors(&self, node_id: &Id) -> Result<Vec<Node>, Error>;
   fn fork(&self, source_id: &Id, to: &Namespace) -> Result<Thread, Error>;
 }
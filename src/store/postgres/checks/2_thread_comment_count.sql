@@ -0,0 +1,69 @@
+-- Checks for the 2_thread_comment_count migration. Run against a database with
+-- all migrations applied:
+--
+--     psql -v ON_ERROR_STOP=1 -f src/store/postgres/checks/2_thread_comment_count.sql
+--
+-- Everything runs in a transaction that is rolled back. A failed check aborts
+-- with an assertion error.
+
+begin;
+
+do $$
+declare
+    author bigint;
+    team bigint;
+    repo bigint;
+    root bigint;
+    early bigint;
+    late bigint;
+    total integer;
+begin
+    insert into public.users (email_primary, password, created_at)
+        values ('check-thread-comment-count@example.com', 'unused', now())
+        returning id into author;
+    insert into public.teams (primary_contact, name)
+        values (author, 'check') returning id into team;
+    insert into public.namespaces (slug, team_id, user_id)
+        values ('check-thread-comment-count', team, author);
+    insert into public.repositories (namespace_slug, visibility_level)
+        values ('check-thread-comment-count', 'private') returning id into repo;
+
+    insert into public.nodes (author_id, created_at, updated_at, body)
+        values (author, now(), now(), 'root') returning id into root;
+
+    -- Comment inserted before the thread row exists
+    insert into public.nodes (author_id, created_at, updated_at, body, source_node_id)
+        values (author, now(), now(), 'early', root) returning id into early;
+
+    insert into public.threads (id, repository_id) values (root, repo);
+    select comment_count into total from public.threads where id = root;
+    assert total = 1, format('after thread insert: expected 1, got %s', total);
+
+    -- Comment inserted after the thread row exists
+    insert into public.nodes (author_id, created_at, updated_at, updated_by, body, source_node_id)
+        values (author, now(), now(), author, 'late', root) returning id into late;
+    select comment_count into total from public.threads where id = root;
+    assert total = 2, format('after comment insert: expected 2, got %s', total);
+
+    -- Editing a comment doesn't change the count
+    update public.nodes set body = 'edited' where id = late;
+    select comment_count into total from public.threads where id = root;
+    assert total = 2, format('after comment edit: expected 2, got %s', total);
+
+    update public.nodes set is_archived = true where id = early;
+    select comment_count into total from public.threads where id = root;
+    assert total = 1, format('after archive: expected 1, got %s', total);
+
+    update public.nodes set is_archived = false where id = early;
+    select comment_count into total from public.threads where id = root;
+    assert total = 2, format('after restore: expected 2, got %s', total);
+
+    delete from public.nodes where id = late;
+    select comment_count into total from public.threads where id = root;
+    assert total = 1, format('after delete: expected 1, got %s', total);
+
+    assert total = public.recount_thread(root),
+        'incremental count differs from recount_thread()';
+end; $$;
+
+rollback;
@@ -0,0 +1,81 @@
+-- Checks for the 4_thread_lock migration. Run against a database with all
+-- migrations applied:
+--
+--     psql -v ON_ERROR_STOP=1 -f src/store/postgres/checks/4_thread_lock.sql
+--
+-- Everything runs in a transaction that is rolled back. A failed check aborts
+-- with an assertion error.
+
+begin;
+
+do $$
+declare
+    author bigint;
+    team bigint;
+    repo bigint;
+    root bigint;
+    existing bigint;
+    other_root bigint;
+begin
+    insert into public.users (email_primary, password, created_at)
+        values ('check-thread-lock@example.com', 'unused', now())
+        returning id into author;
+    insert into public.teams (primary_contact, name)
+        values (author, 'check') returning id into team;
+    insert into public.namespaces (slug, team_id, user_id)
+        values ('check-thread-lock', team, author);
+    insert into public.repositories (namespace_slug, visibility_level)
+        values ('check-thread-lock', 'private') returning id into repo;
+
+    insert into public.nodes (author_id, created_at, updated_at, body)
+        values (author, now(), now(), 'root') returning id into root;
+    insert into public.threads (id, repository_id) values (root, repo);
+    insert into public.nodes (author_id, created_at, updated_at, updated_by, body, source_node_id)
+        values (author, now(), now(), author, 'comment', root) returning id into existing;
+
+    insert into public.nodes (author_id, created_at, updated_at, body)
+        values (author, now(), now(), 'other root') returning id into other_root;
+    insert into public.threads (id, repository_id) values (other_root, repo);
+
+    update public.threads set is_open = false where id = root;
+
+    -- New comment in the locked thread
+    begin
+        insert into public.nodes (author_id, created_at, updated_at, body, source_node_id)
+            values (author, now(), now(), 'rejected', root);
+        raise exception 'comment on locked thread was accepted';
+    exception when insufficient_privilege then
+        null;
+    end;
+
+    -- Reply to a comment in the locked thread, without a source node
+    begin
+        insert into public.nodes (author_id, created_at, updated_at, body, in_reply_to)
+            values (author, now(), now(), 'rejected', existing);
+        raise exception 'reply to comment in locked thread was accepted';
+    exception when insufficient_privilege then
+        null;
+    end;
+
+    -- Reply to the locked thread's root from another thread
+    begin
+        insert into public.nodes (author_id, created_at, updated_at, body, source_node_id, in_reply_to)
+            values (author, now(), now(), 'rejected', other_root, root);
+        raise exception 'reply to locked thread root was accepted';
+    exception when insufficient_privilege then
+        null;
+    end;
+
+    -- Existing comments can still be edited
+    update public.nodes set body = 'edited', updated_at = now() where id = existing;
+
+    -- Open threads still accept comments
+    insert into public.nodes (author_id, created_at, updated_at, body, source_node_id)
+        values (author, now(), now(), 'accepted', other_root);
+
+    update public.threads set is_open = true where id = root;
+    insert into public.nodes (author_id, created_at, updated_at, body, source_node_id, in_reply_to)
+        values (author, now(), now(), 'accepted', root, existing);
+end; $$;
+
+rollback;
@@ -0,0 +1,61 @@
+-- Checks for the 7_thread_pinning migration. Run against a database with all
+-- migrations applied:
+--
+--     psql -v ON_ERROR_STOP=1 -f src/store/postgres/checks/7_thread_pinning.sql
+--
+-- Everything runs in a transaction that is rolled back. A failed check aborts
+-- with an assertion error.
+
+begin;
+
+do $$
+declare
+    author bigint;
+    team bigint;
+    repo bigint;
+    root bigint;
+    attrs_value jsonb;
+    pinned boolean;
+begin
+    insert into public.users (email_primary, password, created_at)
+        values ('check-thread-pinning@example.com', 'unused', now())
+        returning id into author;
+    insert into public.teams (primary_contact, name)
+        values (author, 'check') returning id into team;
+    insert into public.namespaces (slug, team_id, user_id)
+        values ('check-thread-pinning', team, author);
+    insert into public.repositories (namespace_slug, visibility_level)
+        values ('check-thread-pinning', 'private') returning id into repo;
+
+    -- Only a JSON true pins a thread, and no attrs value is rejected
+    foreach attrs_value in array array[
+        null,
+        '{}',
+        '{"pinned": false}',
+        '{"pinned": "true"}',
+        '{"pinned": "no"}',
+        '{"pinned": 2}',
+        '{"pinned": {}}',
+        '{"pinned": null}'
+    ]::jsonb[] loop
+        insert into public.nodes (author_id, created_at, updated_at, body)
+            values (author, now(), now(), 'root') returning id into root;
+        insert into public.threads (id, repository_id, attrs)
+            values (root, repo, attrs_value);
+        select is_pinned into pinned from public.threads where id = root;
+        assert not pinned, format('thread with attrs %s is pinned', attrs_value);
+    end loop;
+
+    insert into public.nodes (author_id, created_at, updated_at, body)
+        values (author, now(), now(), 'root') returning id into root;
+    insert into public.threads (id, repository_id, attrs)
+        values (root, repo, '{"pinned": true}');
+    select is_pinned into pinned from public.threads where id = root;
+    assert pinned, 'thread with pinned true is not pinned';
+
+    update public.threads set attrs = '{"pinned": "yes"}' where id = root;
+    select is_pinned into pinned from public.threads where id = root;
+    assert not pinned, 'thread is still pinned after attrs update';
+end; $$;
+
+rollback;
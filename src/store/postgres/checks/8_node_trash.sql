@@ -0,0 +1,93 @@
+-- Checks for the 8_node_trash migration. Run against a database with all
+-- migrations applied:
+--
+--     psql -v ON_ERROR_STOP=1 -f src/store/postgres/checks/8_node_trash.sql
+--
+-- Everything runs in a transaction that is rolled back. A failed check aborts
+-- with an assertion error.
+
+begin;
+
+do $$
+declare
+    author bigint;
+    team bigint;
+    repo bigint;
+    parent bigint;
+    child bigint;
+    grandchild bigint;
+    recent bigint;
+    referenced bigint;
+    reply bigint;
+    root bigint;
+    archived_time timestamp with time zone;
+    purged integer;
+    copied integer;
+begin
+    insert into public.users (email_primary, password, created_at)
+        values ('check-node-trash@example.com', 'unused', now())
+        returning id into author;
+    insert into public.teams (primary_contact, name)
+        values (author, 'check') returning id into team;
+    insert into public.namespaces (slug, team_id, user_id)
+        values ('check-node-trash', team, author);
+    insert into public.repositories (namespace_slug, visibility_level)
+        values ('check-node-trash', 'private') returning id into repo;
+
+    -- Archiving sets archived_at and restoring clears it
+    insert into public.nodes (author_id, created_at, updated_at, body)
+        values (author, now(), now(), 'parent') returning id into parent;
+    update public.nodes set is_archived = true where id = parent;
+    select archived_at into archived_time from public.nodes where id = parent;
+    assert archived_time is not null, 'archiving did not set archived_at';
+    update public.nodes set is_archived = false where id = parent;
+    select archived_at into archived_time from public.nodes where id = parent;
+    assert archived_time is null, 'restoring did not clear archived_at';
+
+    -- A chain of expired archived replies
+    insert into public.nodes (author_id, created_at, updated_at, body, in_reply_to)
+        values (author, now(), now(), 'child', parent) returning id into child;
+    insert into public.nodes (author_id, created_at, updated_at, body, in_reply_to)
+        values (author, now(), now(), 'grandchild', child) returning id into grandchild;
+
+    -- An archived node that is not expired yet
+    insert into public.nodes (author_id, created_at, updated_at, body)
+        values (author, now(), now(), 'recent') returning id into recent;
+
+    -- An expired archived node with a live reply
+    insert into public.nodes (author_id, created_at, updated_at, body)
+        values (author, now(), now(), 'referenced') returning id into referenced;
+    insert into public.nodes (author_id, created_at, updated_at, body, in_reply_to)
+        values (author, now(), now(), 'reply', referenced) returning id into reply;
+
+    -- An expired archived thread root
+    insert into public.nodes (author_id, created_at, updated_at, body)
+        values (author, now(), now(), 'root') returning id into root;
+    insert into public.threads (id, repository_id) values (root, repo);
+
+    update public.nodes set is_archived = true
+        where id in (parent, child, grandchild, recent, referenced, root);
+    update public.nodes set archived_at = now() - interval '2 days'
+        where id in (parent, child, grandchild, referenced, root);
+
+    purged := public.purge_archived_nodes(interval '1 day');
+    assert purged = 3, format('expected 3 purged nodes, got %s', purged);
+
+    perform 1 from public.nodes where id in (parent, child, grandchild);
+    assert not found, 'expired archived reply chain was not purged';
+    perform 1 from public.nodes where id = recent;
+    assert found, 'archived node within retention was purged';
+    perform 1 from public.nodes where id = referenced;
+    assert found, 'archived node with a live reply was purged';
+    perform 1 from public.nodes where id = root;
+    assert found, 'archived thread root was purged';
+
+    -- Purged nodes are copied to the archive with their archive state
+    select count(*) into copied from archive.nodes
+        where id in (parent, child, grandchild)
+            and is_archived
+            and archived_at = now() - interval '2 days';
+    assert copied = 3, format('expected 3 nodes in archive.nodes, got %s', copied);
+end; $$;
+
+rollback;
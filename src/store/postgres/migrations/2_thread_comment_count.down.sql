@@ -0,0 +1,6 @@
+drop trigger trigger_thread_initial_comment_count on public.threads;
+drop function trigger_thread_initial_comment_count();
+drop trigger trigger_thread_comment_count on public.nodes;
+drop function trigger_thread_comment_count();
+drop function public.recount_thread(bigint);
+alter table public.threads drop column comment_count;
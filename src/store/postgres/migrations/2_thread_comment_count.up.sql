@@ -0,0 +1,77 @@
+-- This migration denormalizes the number of comments in a thread into
+-- `threads.comment_count` so that listing threads doesn't need to count nodes
+-- every time. A comment is any non-archived node whose `source_node_id` is the
+-- thread's ID, excluding the source node itself. The count is maintained
+-- incrementally by a trigger on `public.nodes`. Since `threads.id` is deferred,
+-- comments can be inserted before their thread row exists; a trigger on
+-- `public.threads` counts those when the thread is inserted.
+-- `recount_thread()` rebuilds the value from scratch if it ever drifts.
+
+-- Add the denormalized comment count column
+alter table public.threads
+    add column comment_count integer not null default 0;
+
+/**
+ * recount_thread rebuilds the comment count of a thread from its nodes and
+ * stores it in `threads.comment_count`. Returns the new count.
+ */
+create function public.recount_thread(thread_id bigint, out result integer)
+    returns integer
+    language plpgsql as $body$
+begin
+    select count(*) into result
+        from public.nodes
+        where source_node_id = thread_id
+            and id <> thread_id
+            and not is_archived;
+
+    update public.threads set comment_count = result where id = thread_id;
+end; $body$;
+
+-- Trigger function to keep threads.comment_count updated as nodes change
+create function trigger_thread_comment_count()
+    returns trigger
+    language plpgsql as $body$
+begin
+    if tg_op <> 'INSERT' then
+        if old.source_node_id is not null and old.source_node_id <> old.id and not old.is_archived then
+            -- Node no longer counts as a comment in its old thread
+            update public.threads set comment_count = comment_count - 1
+                where id = old.source_node_id;
+        end if;
+    end if;
+
+    if tg_op <> 'DELETE' then
+        if new.source_node_id is not null and new.source_node_id <> new.id and not new.is_archived then
+            -- Node counts as a comment in its new thread
+            update public.threads set comment_count = comment_count + 1
+                where id = new.source_node_id;
+        end if;
+    end if;
+
+    return null;
+end; $body$;
+
+create trigger trigger_thread_comment_count
+  after insert or update of source_node_id, is_archived or delete
+  on public.nodes
+  for each row
+  execute procedure trigger_thread_comment_count();
+
+-- Trigger function to count comments that were inserted before their thread
+create function trigger_thread_initial_comment_count()
+    returns trigger
+    language plpgsql as $body$
+begin
+    perform public.recount_thread(new.id);
+    return null;
+end; $body$;
+
+create trigger trigger_thread_initial_comment_count
+  after insert
+  on public.threads
+  for each row
+  execute procedure trigger_thread_initial_comment_count();
+
+-- Backfill comment counts for existing threads
+select public.recount_thread(id) from public.threads;
@@ -0,0 +1,2 @@
+drop function public.node_vote_tally(bigint);
+drop table public.node_votes;
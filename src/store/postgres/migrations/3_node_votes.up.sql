@@ -0,0 +1,34 @@
+-- This migration adds votes on nodes. A user can cast at most one vote on a
+-- node, either up (1) or down (-1). Casting another vote replaces the earlier
+-- one, which is enforced by the primary key on (node_id, user_id). Votes are
+-- removed along with the node they target.
+
+/**
+ * Votes cast by users on nodes
+ */
+create table public.node_votes (
+    node_id bigint not null references public.nodes(id) on delete cascade,
+    user_id bigint not null references public.users(id),
+    value smallint not null check (value in (-1, 1)), -- 1 for up, -1 for down
+    created_at timestamp with time zone default now() not null,
+
+    -- Combo primary key. One vote per user per node.
+    primary key (node_id, user_id)
+);
+
+-- Btree index of voters to quickly list a user's votes
+create index node_vote_user_idx on public.node_votes using btree (user_id);
+
+/**
+ * node_vote_tally sums up and down votes cast on a node
+ */
+create function public.node_vote_tally(
+        target_id bigint, out up integer, out down integer
+    )
+    language sql stable as $$
+    select
+        count(*) filter (where value > 0)::integer,
+        count(*) filter (where value < 0)::integer
+    from public.node_votes
+    where node_id = target_id;
+$$;
@@ -0,0 +1,2 @@
+drop trigger trigger_thread_lock on public.nodes;
+drop function trigger_reject_comment_on_locked_thread();
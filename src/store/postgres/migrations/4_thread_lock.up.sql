@@ -0,0 +1,46 @@
+-- This migration enforces `threads.is_open`. A thread that is not open is
+-- locked: new comments are rejected with an `insufficient_privilege` error. A
+-- node is a new comment on a thread if its `source_node_id` is the thread's
+-- ID, or if it replies (`in_reply_to`) to a node in the thread. Existing
+-- comments in a locked thread can still be read and edited.
+
+-- Trigger function to reject new comments on locked threads
+create function trigger_reject_comment_on_locked_thread()
+    returns trigger
+    language plpgsql as $body$
+declare
+    locked_thread bigint;
+begin
+    if tg_op = 'UPDATE' then
+        if old.source_node_id is not distinct from new.source_node_id
+            and old.in_reply_to is not distinct from new.in_reply_to then
+            -- Node stays in the same place, editing is allowed
+            return new;
+        end if;
+    end if;
+
+    if new.source_node_id is not null and new.source_node_id <> new.id then
+        select id into locked_thread from public.threads
+            where id = new.source_node_id and is_open = false;
+    end if;
+
+    if locked_thread is null and new.in_reply_to is not null then
+        -- Thread of the parent node. A node without a source node is a root.
+        select t.id into locked_thread
+            from public.nodes p
+            join public.threads t on t.id = coalesce(p.source_node_id, p.id)
+            where p.id = new.in_reply_to and t.is_open = false;
+    end if;
+
+    if locked_thread is not null then
+        raise exception 'thread % is locked', locked_thread
+            using errcode = 'insufficient_privilege';
+    end if;
+    return new;
+end; $body$;
+
+create trigger trigger_thread_lock
+  before insert or update of source_node_id, in_reply_to
+  on public.nodes
+  for each row
+  execute procedure trigger_reject_comment_on_locked_thread();
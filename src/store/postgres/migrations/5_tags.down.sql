@@ -0,0 +1,2 @@
+drop table public.node_tags;
+drop table public.tags;
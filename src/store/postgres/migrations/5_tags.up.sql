@@ -0,0 +1,35 @@
+-- This migration adds tags for organizing nodes by topic. Tags belong to a
+-- repository and are deduplicated within it by their normalized name, i.e.
+-- with surrounding spaces, tabs and line breaks trimmed and case ignored.
+-- Blank names are rejected. Nodes are linked to tags through `node_tags`.
+-- Links are removed along with the node or tag they reference. Nodes carry no
+-- repository, so the database does not check that a node and its tags belong
+-- to the same repository; callers must enforce that.
+
+/**
+ * Tags info
+ */
+create table public.tags (
+    id bigint default generate_id() not null primary key,
+    repository_id bigint not null references public.repositories (id),
+    name text not null check (btrim(name, E' \t\r\n') <> ''),
+    created_at timestamp with time zone default now() not null
+);
+
+-- Unique index of normalized tag names to deduplicate tags within a repository
+create unique index tag_repository_name_idx
+    on public.tags using btree (repository_id, lower(btrim(name, E' \t\r\n')));
+
+/**
+ * Link table for storing tags of nodes
+ */
+create table public.node_tags (
+    node_id bigint not null references public.nodes(id) on delete cascade,
+    tag_id bigint not null references public.tags(id) on delete cascade,
+
+    -- Combo primary key
+    primary key (node_id, tag_id)
+);
+
+-- Btree index of tags in node_tags to quickly lookup nodes with a tag
+create index node_tag_tag_id_idx on public.node_tags using btree (tag_id);
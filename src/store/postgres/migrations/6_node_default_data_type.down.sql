@@ -0,0 +1,2 @@
+alter table public.nodes
+    alter column data_type drop default;
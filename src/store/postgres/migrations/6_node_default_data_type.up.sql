@@ -0,0 +1,6 @@
+-- Default the data type of nodes to `markdown`, the node data type created in
+-- the genesis migration, so that nodes inserted without one are still
+-- renderable. Explicit empty data types are still rejected by the foreign key
+-- on `node_data_types`.
+alter table public.nodes
+    alter column data_type set default 'markdown';
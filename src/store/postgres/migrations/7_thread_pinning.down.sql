@@ -0,0 +1,2 @@
+drop index public.thread_repository_pinned_idx;
+alter table public.threads drop column is_pinned;
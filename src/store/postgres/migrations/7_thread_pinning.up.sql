@@ -0,0 +1,16 @@
+-- This migration lets threads be pinned by setting `"pinned": true` in
+-- `threads.attrs`. Only a JSON `true` pins a thread; any other value, or no
+-- value, leaves it unpinned. The flag is exposed as the generated column
+-- `threads.is_pinned` so that thread listings can be ordered pinned-first and
+-- then by recency using an index. Thread IDs come from `generate_id()` and are
+-- time ordered, so recency is the same as descending ID.
+
+-- Add a generated column for the pinned flag in thread attrs
+alter table public.threads
+    add column is_pinned boolean not null
+        generated always as (coalesce(attrs @> '{"pinned": true}', false)) stored;
+
+-- Btree index to list threads in a repository with pinned threads first, then
+-- newest first
+create index thread_repository_pinned_idx
+    on public.threads using btree (repository_id, is_pinned desc, id desc);
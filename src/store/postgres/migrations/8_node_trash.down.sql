@@ -0,0 +1,21 @@
+drop function public.purge_archived_nodes(interval);
+drop trigger trigger_node_archived_at on public.nodes;
+drop function trigger_node_archived_at();
+create or replace function trigger_archive_node_on_delete()
+    returns trigger
+    language plpgsql as $body$
+begin
+    insert into archive.nodes
+        (id, author_id, data_type, source_node_id, created_at, in_reply_to,
+        attrs, updated_at, updated_by, subject, body, rich_data)
+    values
+        (old.id, old.author_id, old.data_type, old.source_node_id, old.created_at, old.in_reply_to,
+        old.attrs, old.updated_at, old.updated_by, old.subject, old.body, old.rich_data);
+    return old;
+end; $body$;
+alter table archive.nodes drop column archived_at;
+drop index public.thread_merge_node_idx;
+drop index public.thread_forked_from_node_idx;
+drop index public.node_in_reply_to_idx;
+drop index public.node_archived_at_idx;
+alter table public.nodes drop column archived_at;
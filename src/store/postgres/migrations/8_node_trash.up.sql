@@ -0,0 +1,113 @@
+-- This migration turns the `nodes.is_archived` soft delete into a trash with a
+-- retention window. Archiving a node records the time in `nodes.archived_at`,
+-- and un-archiving it within the window restores it. `purge_archived_nodes()`
+-- permanently deletes nodes that have been archived for longer than the given
+-- retention, which in turn copies them to the `archive` schema through the
+-- trigger from the archive migration. Archived nodes that other nodes or
+-- threads still reference are skipped by the purge, unless those referrers
+-- are purged in the same call.
+--
+-- `archive.nodes` was created with `like public.nodes`, which copies the
+-- `not null` constraint on `is_archived` but not its default. The archive
+-- trigger didn't copy `is_archived`, so deleting any node failed. This
+-- migration replaces the trigger to copy `is_archived` and `archived_at` too.
+
+-- Add a column to keep track of when a node was archived
+alter table public.nodes
+    add column archived_at timestamp with time zone;
+
+-- Backfill archival time for nodes that are already archived
+update public.nodes set archived_at = now() where is_archived;
+
+-- Partial btree index of archival time to quickly find expired archived nodes
+create index node_archived_at_idx on public.nodes using btree (archived_at)
+    where is_archived;
+
+-- Btree index of parent nodes to quickly lookup replies
+create index node_in_reply_to_idx on public.nodes using btree (in_reply_to);
+
+-- Btree indexes of nodes threads were forked from or merged into, to quickly
+-- check whether a node is referenced by a thread
+create index thread_forked_from_node_idx on public.threads using btree (forked_from_node);
+create index thread_merge_node_idx on public.threads using btree (merge_node);
+
+-- Add a column to keep track of when an archived node was archived
+alter table archive.nodes
+    add column archived_at timestamp with time zone;
+
+-- Replace trigger function to also copy the archive state to archive.nodes
+create or replace function trigger_archive_node_on_delete()
+    returns trigger
+    language plpgsql as $body$
+begin
+    insert into archive.nodes
+        (id, author_id, data_type, source_node_id, created_at, in_reply_to,
+        attrs, updated_at, updated_by, subject, body, rich_data,
+        is_archived, archived_at)
+    values
+        (old.id, old.author_id, old.data_type, old.source_node_id, old.created_at, old.in_reply_to,
+        old.attrs, old.updated_at, old.updated_by, old.subject, old.body, old.rich_data,
+        old.is_archived, old.archived_at);
+    return old;
+end; $body$;
+
+-- Trigger function to keep nodes.archived_at in sync with nodes.is_archived
+create function trigger_node_archived_at()
+    returns trigger
+    language plpgsql as $body$
+begin
+    if new.is_archived and new.archived_at is null then
+        new.archived_at := now();
+    elsif not new.is_archived then
+        new.archived_at := null;
+    end if;
+    return new;
+end; $body$;
+
+create trigger trigger_node_archived_at
+  before insert or update of is_archived
+  on public.nodes
+  for each row
+  execute procedure trigger_node_archived_at();
+
+/**
+ * purge_archived_nodes deletes nodes archived longer ago than `retention`.
+ * Returns the number of nodes deleted.
+ *
+ * Each pass only deletes nodes that are not referenced by the time the pass
+ * starts, so a chain of expired archived replies is deleted one level per
+ * pass. Passes repeat until nothing more can be deleted.
+ */
+create function public.purge_archived_nodes(retention interval, out result integer)
+    returns integer
+    language plpgsql as $body$
+declare
+    deleted integer;
+begin
+    result := 0;
+    loop
+        delete from public.nodes n
+            where n.is_archived
+                and n.archived_at < now() - retention
+                and not exists (
+                    select 1 from public.nodes c
+                        where c.in_reply_to = n.id and c.id <> n.id
+                )
+                and not exists (
+                    select 1 from public.nodes c
+                        where c.source_node_id = n.id and c.id <> n.id
+                )
+                and not exists (
+                    select 1 from public.threads t where t.id = n.id
+                )
+                and not exists (
+                    select 1 from public.threads t where t.forked_from_node = n.id
+                )
+                and not exists (
+                    select 1 from public.threads t where t.merge_node = n.id
+                );
+        get diagnostics deleted = row_count;
+        exit when deleted = 0;
+        result := result + deleted;
+    end loop;
+end; $body$;
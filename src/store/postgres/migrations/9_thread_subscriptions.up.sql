@@ -0,0 +1,22 @@
+-- This migration lets users follow threads. Subscribing is inserting a row in
+-- `thread_subscriptions` and unsubscribing is deleting it; the primary key on
+-- (thread_id, user_id) keeps a user subscribed to a thread at most once.
+-- Subscriptions are removed along with their thread. Notifying subscribers of
+-- new comments is left to the application.
+
+/**
+ * Link table for storing users subscribed to threads
+ */
+create table public.thread_subscriptions (
+    thread_id bigint not null references public.threads(id) on delete cascade,
+    user_id bigint not null references public.users(id),
+    created_at timestamp with time zone default now() not null,
+
+    -- Combo primary key
+    primary key (thread_id, user_id)
+);
+
+-- Btree index of users in thread_subscriptions to quickly list a user's
+-- subscriptions
+create index thread_subscription_user_id_idx
+    on public.thread_subscriptions using btree (user_id);